@@ -0,0 +1,89 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/notify"
+)
+
+// PutBucketNotificationHandler - PUT Bucket notification.
+// ----------
+// This implementation stores the NotificationConfiguration document
+// supplied by the client and starts (or reuses) a background sink
+// queue for every ARN it references, see pkg/notify for the fan-out.
+func (api storageAPI) PutBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := router.Vars(r)["bucket"]
+
+	if _, err := api.Filesystem.GetBucketMetadata(bucket); err != nil {
+		writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
+		return
+	}
+
+	var config notify.BucketNotificationConfig
+	if err := xml.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+
+	if err := api.Notifier.SetBucketConfig(bucket, config); err != nil {
+		// No dedicated error code exists for "a referenced ARN's sink
+		// could not be reached", the configuration document itself is
+		// the only thing the client controls here, so report it the
+		// same way a malformed document would be.
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetBucketNotificationHandler - GET Bucket notification.
+// ----------
+// Returns the NotificationConfiguration previously set on the bucket,
+// or an empty document if none was configured.
+func (api storageAPI) GetBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := router.Vars(r)["bucket"]
+
+	if _, err := api.Filesystem.GetBucketMetadata(bucket); err != nil {
+		writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
+		return
+	}
+
+	config := api.Notifier.GetBucketConfig(bucket)
+	encodedSuccessResponse := encodeResponse(config)
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// DeleteBucketNotificationHandler - DELETE Bucket notification.
+// ----------
+// Clears the bucket's NotificationConfiguration, every running sink
+// queue is left untouched, including queues no other bucket references.
+func (api storageAPI) DeleteBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := router.Vars(r)["bucket"]
+
+	if _, err := api.Filesystem.GetBucketMetadata(bucket); err != nil {
+		writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
+		return
+	}
+
+	api.Notifier.DeleteBucketConfig(bucket)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,348 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+
+	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/probe"
+)
+
+// azureGateway translates the S3 API exposed by storageAPI onto Azure
+// Blob Storage. It is selected with "minio gateway azure" or
+// MINIO_GATEWAY=azure, and reads credentials from the standard
+// AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_ACCESS_KEY environment
+// variables. S3 buckets map onto Azure containers, S3 objects onto
+// block blobs.
+type azureGateway struct {
+	rootPath string
+	client   storage.BlobStorageClient
+
+	// policyMu guards policyDocs - Azure has no equivalent of an S3 JSON
+	// bucket policy document to read back (SetBucketMetadata only
+	// translates it into a container access level), so the document is
+	// cached here instead, see s3Gateway.policyDocs for the same
+	// tradeoff.
+	policyMu   sync.RWMutex
+	policyDocs map[string]string
+}
+
+// newAzureGateway initializes an Azure Blob Storage client.
+func newAzureGateway(filesystem fs.Filesystem) (ObjectLayer, *probe.Error) {
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	key := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
+	if account == "" || key == "" {
+		return nil, probe.NewError(fmt.Errorf("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_ACCESS_KEY must be set for the azure gateway backend"))
+	}
+	client, e := storage.NewBasicClient(account, key)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &azureGateway{
+		rootPath:   filesystem.GetRootPath(),
+		client:     client.GetBlobService(),
+		policyDocs: make(map[string]string),
+	}, nil
+}
+
+func (a *azureGateway) GetRootPath() string {
+	return a.rootPath
+}
+
+func (a *azureGateway) ListBuckets() ([]fs.BucketMetadata, *probe.Error) {
+	resp, e := a.client.ListContainers(storage.ListContainersParameters{})
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	var metadata []fs.BucketMetadata
+	for _, c := range resp.Containers {
+		metadata = append(metadata, fs.BucketMetadata{Name: c.Name})
+	}
+	return metadata, nil
+}
+
+func (a *azureGateway) MakeBucket(bucket string) *probe.Error {
+	if e := a.client.GetContainerReference(bucket).Create(nil); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+func (a *azureGateway) GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error) {
+	container := a.client.GetContainerReference(bucket)
+	if e := container.GetProperties(nil); e != nil {
+		return fs.BucketMetadata{}, probe.NewError(e)
+	}
+	return fs.BucketMetadata{Name: bucket}, nil
+}
+
+// SetBucketMetadata translates the "policy" key onto the container's
+// public access level - Azure has no equivalent of S3's JSON bucket
+// policy grammar, so only the common "anyone can read" case is
+// recognized, a policy document that doesn't obviously grant public
+// read leaves the container private rather than guessing at a partial
+// translation.
+func (a *azureGateway) SetBucketMetadata(bucket string, metadata map[string]string) *probe.Error {
+	policyJSON, ok := metadata["policy"]
+	if !ok {
+		return nil
+	}
+	accessType := storage.ContainerAccessTypePrivate
+	if strings.Contains(policyJSON, `"Principal":"*"`) && !strings.Contains(policyJSON, `"Effect":"Deny"`) {
+		accessType = storage.ContainerAccessTypeBlob
+	}
+	container := a.client.GetContainerReference(bucket)
+	if e := container.SetPermissions(storage.ContainerPermissions{AccessType: accessType}, nil); e != nil {
+		return probe.NewError(e)
+	}
+	a.policyMu.Lock()
+	a.policyDocs[bucket] = policyJSON
+	a.policyMu.Unlock()
+	return nil
+}
+
+// GetBucketPolicy returns the document most recently passed to
+// SetBucketMetadata for bucket, see the policyDocs field comment.
+func (a *azureGateway) GetBucketPolicy(bucket string) (string, *probe.Error) {
+	a.policyMu.RLock()
+	defer a.policyMu.RUnlock()
+	return a.policyDocs[bucket], nil
+}
+
+func (a *azureGateway) DeleteBucket(bucket string) *probe.Error {
+	if e := a.client.GetContainerReference(bucket).Delete(nil); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+func (a *azureGateway) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (fs.ListObjectsResult, *probe.Error) {
+	resp, e := a.client.GetContainerReference(bucket).ListBlobs(storage.ListBlobsParameters{
+		Prefix:     prefix,
+		Marker:     marker,
+		Delimiter:  delimiter,
+		MaxResults: uint(maxKeys),
+	})
+	if e != nil {
+		return fs.ListObjectsResult{}, probe.NewError(e)
+	}
+	var result fs.ListObjectsResult
+	for _, blob := range resp.Blobs {
+		result.Objects = append(result.Objects, fs.ObjectMetadata{
+			Bucket:  bucket,
+			Object:  blob.Name,
+			Size:    blob.Properties.ContentLength,
+			Md5:     blob.Properties.Etag,
+			Created: blob.Properties.LastModified.Time,
+		})
+	}
+	result.IsTruncated = resp.NextMarker != ""
+	return result, nil
+}
+
+func (a *azureGateway) GetObject(w io.Writer, bucket, object string, startOffset int64) (int64, *probe.Error) {
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	reader, e := blob.GetRange(&storage.GetBlobRangeOptions{
+		Range: &storage.BlobRange{Start: uint64(startOffset)},
+	})
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	defer reader.Close()
+	n, e := io.Copy(w, reader)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	return n, nil
+}
+
+func (a *azureGateway) GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error) {
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	if e := blob.GetProperties(nil); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{
+		Bucket:  bucket,
+		Object:  object,
+		Size:    blob.Properties.ContentLength,
+		Md5:     blob.Properties.Etag,
+		Created: blob.Properties.LastModified.Time,
+	}, nil
+}
+
+func (a *azureGateway) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	body, e := ioutil.ReadAll(data)
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	if e = blob.CreateBlockBlobFromReader(ioutil.NopCloser(bytes.NewReader(body)), nil); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{Bucket: bucket, Object: object, Size: int64(len(body))}, nil
+}
+
+func (a *azureGateway) DeleteObject(bucket, object string) *probe.Error {
+	if e := a.client.GetContainerReference(bucket).GetBlobReference(object).Delete(nil); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// CopyObject uses the native Azure server side copy instead of
+// streaming the blob through this process.
+func (a *azureGateway) CopyObject(bucket, object, sourceBucket, sourceObject string, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	src := a.client.GetContainerReference(sourceBucket).GetBlobReference(sourceObject)
+	dst := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	if e := dst.Copy(src.GetURL(), nil); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return a.GetObjectMetadata(bucket, object)
+}
+
+// Azure Blob Storage has no notion of S3 style multipart uploads, it
+// uses put-block/put-block-list against a single blob instead - every
+// method below is a thin translation onto that native mechanism rather
+// than a passthrough to an equivalent Azure multipart API.
+
+// azureBlockID derives the base64 block ID PutBlock/PutBlockList expect
+// from an S3 upload ID and part number. uploadID namespaces the block
+// so two concurrent multipart uploads against the same blob (a client
+// retrying after a timeout, or two independent uploads to the same key)
+// can never stage over each other's parts. Block IDs for a single blob
+// must all be the same length, so both fields are fixed width before
+// encoding - uploadID is always the 32 hex characters NewMultipartUpload
+// generates.
+func azureBlockID(uploadID string, partNumber int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%010d", uploadID, partNumber)))
+}
+
+// azurePartNumber recovers the part number azureBlockID encoded, only
+// for blocks staged under uploadID - blockID is ignored (false is
+// returned) when it belongs to a different, possibly still in-flight,
+// multipart upload against the same blob.
+func azurePartNumber(uploadID, blockID string) (int, bool) {
+	decoded, e := base64.StdEncoding.DecodeString(blockID)
+	if e != nil || !strings.HasPrefix(string(decoded), uploadID) {
+		return 0, false
+	}
+	var partNumber int
+	if _, e = fmt.Sscanf(strings.TrimPrefix(string(decoded), uploadID), "%010d", &partNumber); e != nil {
+		return 0, false
+	}
+	return partNumber, true
+}
+
+// ListMultipartUploads has no native Azure equivalent - uncommitted
+// blocks are only enumerable per blob, not across a container, so there
+// is no way to discover which blobs have an upload in progress without
+// already knowing their names. Report an empty, successful listing
+// rather than failing every ListMultipartUploads request outright.
+func (a *azureGateway) ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error) {
+	return resources, nil
+}
+
+// NewMultipartUpload has no Azure equivalent to call - blocks are
+// staged directly against the destination blob name, the upload ID
+// exists only so later calls can be routed back here, it never reaches
+// Azure itself.
+func (a *azureGateway) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	buf := make([]byte, 16)
+	if _, e := rand.Read(buf); e != nil {
+		return "", probe.NewError(e)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PutObjectPart translates to the blob's own PutBlock, staging part
+// data as an uncommitted block, CompleteMultipartUpload later commits
+// the blocks in part-number order via PutBlockList.
+func (a *azureGateway) PutObjectPart(bucket, object, uploadID string, partNumber int, expectedMD5Sum string, size int64, data io.Reader) (fs.PartMetadata, *probe.Error) {
+	body, e := ioutil.ReadAll(data)
+	if e != nil {
+		return fs.PartMetadata{}, probe.NewError(e)
+	}
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	if e = blob.PutBlock(azureBlockID(uploadID, partNumber), body, nil); e != nil {
+		return fs.PartMetadata{}, probe.NewError(e)
+	}
+	return fs.PartMetadata{PartNumber: partNumber, Size: int64(len(body))}, nil
+}
+
+// ListObjectParts translates to the blob's own GetBlockList, filtered
+// to the blocks still uncommitted for this upload.
+func (a *azureGateway) ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error) {
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	blockList, e := blob.GetBlockList(storage.BlockListTypeUncommitted)
+	if e != nil {
+		return resources, probe.NewError(e)
+	}
+	for _, block := range blockList.UncommittedBlocks {
+		partNumber, ok := azurePartNumber(resources.UploadID, block.Name)
+		if !ok {
+			continue
+		}
+		resources.Parts = append(resources.Parts, fs.PartMetadata{
+			PartNumber: partNumber,
+			Size:       block.Size,
+		})
+	}
+	sort.Slice(resources.Parts, func(i, j int) bool { return resources.Parts[i].PartNumber < resources.Parts[j].PartNumber })
+	return resources, nil
+}
+
+// CompleteMultipartUpload commits every staged block, in ascending
+// part-number order, via the blob's own PutBlockList.
+func (a *azureGateway) CompleteMultipartUpload(bucket, object, uploadID string, parts map[int]string) (fs.ObjectMetadata, *probe.Error) {
+	partNumbers := make([]int, 0, len(parts))
+	for partNumber := range parts {
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+
+	blocks := make([]storage.Block, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		blocks = append(blocks, storage.Block{ID: azureBlockID(uploadID, partNumber), Status: storage.BlockStatusUncommitted})
+	}
+
+	blob := a.client.GetContainerReference(bucket).GetBlobReference(object)
+	if e := blob.PutBlockList(blocks, nil); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return a.GetObjectMetadata(bucket, object)
+}
+
+// AbortMultipartUpload has no direct Azure equivalent - uncommitted
+// blocks that are never referenced by a PutBlockList call are garbage
+// collected by the service on its own (Azure expires them after about a
+// week), there is nothing to delete explicitly.
+func (a *azureGateway) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	return nil
+}
@@ -0,0 +1,32 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "github.com/minio/minio/pkg/fs"
+
+// fsGateway is the default ObjectLayer backend, it simply forwards every
+// call onto the local POSIX filesystem driver. It exists so that
+// configureServerHandler only ever deals with the ObjectLayer interface,
+// never with fs.Filesystem directly.
+type fsGateway struct {
+	fs.Filesystem
+}
+
+// newFSGateway wraps filesystem as an ObjectLayer.
+func newFSGateway(filesystem fs.Filesystem) ObjectLayer {
+	return &fsGateway{Filesystem: filesystem}
+}
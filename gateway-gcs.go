@@ -0,0 +1,427 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
+
+	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/probe"
+)
+
+// gcsGateway translates the S3 API exposed by storageAPI onto Google
+// Cloud Storage. It is selected with "minio gateway gcs" or
+// MINIO_GATEWAY=gcs, and reads credentials from the file named by
+// GOOGLE_APPLICATION_CREDENTIALS.
+type gcsGateway struct {
+	rootPath string
+	ctx      context.Context
+	client   *storage.Client
+
+	// policyMu guards policyDocs - GCS has no equivalent of an S3 JSON
+	// bucket policy document to read back (SetBucketMetadata only
+	// translates it into an ACL rule), so the document is cached here
+	// instead, see s3Gateway.policyDocs for the same tradeoff.
+	policyMu   sync.RWMutex
+	policyDocs map[string]string
+}
+
+// newGCSGateway initializes a Cloud Storage client using Application
+// Default Credentials.
+func newGCSGateway(filesystem fs.Filesystem) (ObjectLayer, *probe.Error) {
+	ctx := context.Background()
+	client, e := storage.NewClient(ctx)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	// Touch google.DefaultClient once so an explicit and descriptive
+	// error is returned early when GOOGLE_APPLICATION_CREDENTIALS is
+	// missing or invalid, instead of failing on the first request.
+	if _, e = google.DefaultClient(ctx); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &gcsGateway{
+		rootPath:   filesystem.GetRootPath(),
+		ctx:        ctx,
+		client:     client,
+		policyDocs: make(map[string]string),
+	}, nil
+}
+
+func (g *gcsGateway) GetRootPath() string {
+	return g.rootPath
+}
+
+// ListBuckets translates to the equivalent Buckets() iterator scoped to
+// the project named by GOOGLE_CLOUD_PROJECT.
+func (g *gcsGateway) ListBuckets() ([]fs.BucketMetadata, *probe.Error) {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return nil, probe.NewError(fmt.Errorf("GOOGLE_CLOUD_PROJECT must be set for the gcs gateway backend"))
+	}
+	var metadata []fs.BucketMetadata
+	it := g.client.Buckets(g.ctx, project)
+	for {
+		attrs, e := it.Next()
+		if e == iterator.Done {
+			break
+		}
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		metadata = append(metadata, fs.BucketMetadata{
+			Name:    attrs.Name,
+			Created: attrs.Created,
+		})
+	}
+	return metadata, nil
+}
+
+func (g *gcsGateway) MakeBucket(bucket string) *probe.Error {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return probe.NewError(fmt.Errorf("GOOGLE_CLOUD_PROJECT must be set for the gcs gateway backend"))
+	}
+	if e := g.client.Bucket(bucket).Create(g.ctx, project, nil); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+func (g *gcsGateway) GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error) {
+	attrs, e := g.client.Bucket(bucket).Attrs(g.ctx)
+	if e != nil {
+		return fs.BucketMetadata{}, probe.NewError(e)
+	}
+	return fs.BucketMetadata{Name: attrs.Name, Created: attrs.Created}, nil
+}
+
+// SetBucketMetadata translates the "policy" key onto the bucket's
+// default object ACL - GCS has no equivalent of S3's JSON bucket policy
+// grammar, so only the common "anyone can read" case is recognized, a
+// policy document that doesn't obviously grant public read leaves the
+// bucket private rather than guessing at a partial translation.
+func (g *gcsGateway) SetBucketMetadata(bucket string, metadata map[string]string) *probe.Error {
+	policyJSON, ok := metadata["policy"]
+	if !ok {
+		return nil
+	}
+	aclRule := storage.ACLRule{Entity: storage.AllUsers, Role: storage.RoleReader}
+	if strings.Contains(policyJSON, `"Effect":"Deny"`) || !strings.Contains(policyJSON, `"Principal":"*"`) {
+		if e := g.client.Bucket(bucket).DefaultObjectACL().Delete(g.ctx, storage.AllUsers); e != nil {
+			return probe.NewError(e)
+		}
+		g.policyMu.Lock()
+		g.policyDocs[bucket] = policyJSON
+		g.policyMu.Unlock()
+		return nil
+	}
+	if e := g.client.Bucket(bucket).DefaultObjectACL().Set(g.ctx, aclRule.Entity, aclRule.Role); e != nil {
+		return probe.NewError(e)
+	}
+	g.policyMu.Lock()
+	g.policyDocs[bucket] = policyJSON
+	g.policyMu.Unlock()
+	return nil
+}
+
+// GetBucketPolicy returns the document most recently passed to
+// SetBucketMetadata for bucket, see the policyDocs field comment.
+func (g *gcsGateway) GetBucketPolicy(bucket string) (string, *probe.Error) {
+	g.policyMu.RLock()
+	defer g.policyMu.RUnlock()
+	return g.policyDocs[bucket], nil
+}
+
+func (g *gcsGateway) DeleteBucket(bucket string) *probe.Error {
+	if e := g.client.Bucket(bucket).Delete(g.ctx); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// ListObjects lists in the lexicographic order GCS already returns
+// objects in, skipping everything up to and including marker so paging
+// through a bucket larger than maxKeys resumes where the previous page
+// left off, the same contract the local filesystem driver honors.
+func (g *gcsGateway) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (fs.ListObjectsResult, *probe.Error) {
+	var result fs.ListObjectsResult
+	it := g.client.Bucket(bucket).Objects(g.ctx, &storage.Query{Prefix: prefix, Delimiter: delimiter})
+	count := 0
+	for {
+		attrs, e := it.Next()
+		if e == iterator.Done {
+			break
+		}
+		if e != nil {
+			return fs.ListObjectsResult{}, probe.NewError(e)
+		}
+		if marker != "" && attrs.Name <= marker {
+			continue
+		}
+		if count >= maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		result.Objects = append(result.Objects, fs.ObjectMetadata{
+			Bucket:  bucket,
+			Object:  attrs.Name,
+			Size:    attrs.Size,
+			Md5:     fmt.Sprintf("%x", attrs.MD5),
+			Created: attrs.Updated,
+		})
+		count++
+	}
+	return result, nil
+}
+
+func (g *gcsGateway) GetObject(w io.Writer, bucket, object string, startOffset int64) (int64, *probe.Error) {
+	reader, e := g.client.Bucket(bucket).Object(object).NewRangeReader(g.ctx, startOffset, -1)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	defer reader.Close()
+	n, e := io.Copy(w, reader)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	return n, nil
+}
+
+func (g *gcsGateway) GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error) {
+	attrs, e := g.client.Bucket(bucket).Object(object).Attrs(g.ctx)
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{
+		Bucket:  bucket,
+		Object:  object,
+		Size:    attrs.Size,
+		Md5:     fmt.Sprintf("%x", attrs.MD5),
+		Created: attrs.Updated,
+	}, nil
+}
+
+func (g *gcsGateway) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	writer := g.client.Bucket(bucket).Object(object).NewWriter(g.ctx)
+	n, e := io.Copy(writer, data)
+	if e != nil {
+		writer.Close()
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	if e = writer.Close(); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{Bucket: bucket, Object: object, Size: n}, nil
+}
+
+func (g *gcsGateway) DeleteObject(bucket, object string) *probe.Error {
+	if e := g.client.Bucket(bucket).Object(object).Delete(g.ctx); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// CopyObject uses the native GCS server side copy instead of streaming
+// the object through this process.
+func (g *gcsGateway) CopyObject(bucket, object, sourceBucket, sourceObject string, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	src := g.client.Bucket(sourceBucket).Object(sourceObject)
+	dst := g.client.Bucket(bucket).Object(object)
+	attrs, e := dst.CopierFrom(src).Run(g.ctx)
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{
+		Bucket:  bucket,
+		Object:  object,
+		Size:    attrs.Size,
+		Md5:     fmt.Sprintf("%x", attrs.MD5),
+		Created: attrs.Updated,
+	}, nil
+}
+
+// gcsMultipartMaxParts is the largest number of source objects
+// storage.Composer accepts in one call - a multipart upload with more
+// parts than this cannot be completed against GCS and is rejected by
+// CompleteMultipartUpload up front rather than silently truncated.
+const gcsMultipartMaxParts = 32
+
+// gcsPartPrefix namespaces the temporary per-part objects a multipart
+// upload writes while it is in progress, under a path no S3 client can
+// address directly.
+func gcsPartPrefix(object, uploadID string) string {
+	return fmt.Sprintf(".minio.sys/multipart/%s/%s/", object, uploadID)
+}
+
+func gcsPartName(object, uploadID string, partNumber int) string {
+	return fmt.Sprintf("%s%05d", gcsPartPrefix(object, uploadID), partNumber)
+}
+
+// NewMultipartUpload has no GCS equivalent, it hands back an opaque
+// upload ID that only ever names the temporary part objects
+// PutObjectPart writes under - CompleteMultipartUpload composes them
+// into the final object, AbortMultipartUpload deletes them.
+func (g *gcsGateway) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	buf := make([]byte, 16)
+	if _, e := rand.Read(buf); e != nil {
+		return "", probe.NewError(e)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PutObjectPart writes part data to a temporary object under the
+// upload's part prefix, CompleteMultipartUpload composes these back
+// together in part-number order.
+func (g *gcsGateway) PutObjectPart(bucket, object, uploadID string, partNumber int, expectedMD5Sum string, size int64, data io.Reader) (fs.PartMetadata, *probe.Error) {
+	writer := g.client.Bucket(bucket).Object(gcsPartName(object, uploadID, partNumber)).NewWriter(g.ctx)
+	n, e := io.Copy(writer, data)
+	if e != nil {
+		writer.Close()
+		return fs.PartMetadata{}, probe.NewError(e)
+	}
+	if e = writer.Close(); e != nil {
+		return fs.PartMetadata{}, probe.NewError(e)
+	}
+	return fs.PartMetadata{PartNumber: partNumber, ETag: writer.Attrs().Etag, Size: n}, nil
+}
+
+// ListObjectParts lists the temporary part objects written so far for
+// uploadID.
+func (g *gcsGateway) ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error) {
+	it := g.client.Bucket(bucket).Objects(g.ctx, &storage.Query{Prefix: gcsPartPrefix(object, resources.UploadID)})
+	for {
+		attrs, e := it.Next()
+		if e == iterator.Done {
+			break
+		}
+		if e != nil {
+			return resources, probe.NewError(e)
+		}
+		partNumber, e := strconv.Atoi(strings.TrimPrefix(attrs.Name, gcsPartPrefix(object, resources.UploadID)))
+		if e != nil {
+			continue
+		}
+		resources.Parts = append(resources.Parts, fs.PartMetadata{
+			PartNumber: partNumber,
+			ETag:       attrs.Etag,
+			Size:       attrs.Size,
+		})
+	}
+	sort.Slice(resources.Parts, func(i, j int) bool { return resources.Parts[i].PartNumber < resources.Parts[j].PartNumber })
+	return resources, nil
+}
+
+// CompleteMultipartUpload composes every uploaded part, in ascending
+// part-number order, into the final object and removes the temporary
+// part objects.
+func (g *gcsGateway) CompleteMultipartUpload(bucket, object, uploadID string, parts map[int]string) (fs.ObjectMetadata, *probe.Error) {
+	if len(parts) > gcsMultipartMaxParts {
+		return fs.ObjectMetadata{}, probe.NewError(fmt.Errorf("gcs gateway: %d parts exceeds the %d parts a single compose can merge", len(parts), gcsMultipartMaxParts))
+	}
+	partNumbers := make([]int, 0, len(parts))
+	for partNumber := range parts {
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+
+	sources := make([]*storage.ObjectHandle, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		sources = append(sources, g.client.Bucket(bucket).Object(gcsPartName(object, uploadID, partNumber)))
+	}
+
+	dst := g.client.Bucket(bucket).Object(object)
+	if _, e := dst.ComposerFrom(sources...).Run(g.ctx); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+
+	for _, src := range sources {
+		src.Delete(g.ctx)
+	}
+	return g.GetObjectMetadata(bucket, object)
+}
+
+// AbortMultipartUpload deletes every temporary part object written for
+// uploadID.
+func (g *gcsGateway) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	it := g.client.Bucket(bucket).Objects(g.ctx, &storage.Query{Prefix: gcsPartPrefix(object, uploadID)})
+	for {
+		attrs, e := it.Next()
+		if e == iterator.Done {
+			break
+		}
+		if e != nil {
+			return probe.NewError(e)
+		}
+		if e = g.client.Bucket(bucket).Object(attrs.Name).Delete(g.ctx); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	return nil
+}
+
+// ListMultipartUploads has no native GCS equivalent, in-progress
+// uploads are instead discovered from the temporary part objects
+// PutObjectPart writes under ".minio.sys/multipart/<object>/<uploadID>/".
+func (g *gcsGateway) ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error) {
+	it := g.client.Bucket(bucket).Objects(g.ctx, &storage.Query{Prefix: ".minio.sys/multipart/"})
+	seen := make(map[string]bool)
+	for {
+		attrs, e := it.Next()
+		if e == iterator.Done {
+			break
+		}
+		if e != nil {
+			return resources, probe.NewError(e)
+		}
+		// attrs.Name is ".minio.sys/multipart/<object>/<uploadID>/<partNumber>".
+		// <object> may itself contain "/", so split from the right.
+		trimmed := strings.TrimPrefix(attrs.Name, ".minio.sys/multipart/")
+		partSlash := strings.LastIndex(trimmed, "/")
+		if partSlash < 0 {
+			continue
+		}
+		uploadSlash := strings.LastIndex(trimmed[:partSlash], "/")
+		if uploadSlash < 0 {
+			continue
+		}
+		object, uploadID := trimmed[:uploadSlash], trimmed[uploadSlash+1:partSlash]
+		key := object + "/" + uploadID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		resources.Uploads = append(resources.Uploads, fs.UploadMetadata{
+			Key:       object,
+			UploadID:  uploadID,
+			Initiated: attrs.Created,
+		})
+	}
+	return resources, nil
+}
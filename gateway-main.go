@@ -0,0 +1,78 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio/pkg/fs"
+)
+
+var gatewayCmd = cli.Command{
+	Name:   "gateway",
+	Usage:  "Start object storage gateway.",
+	Action: gatewayMain,
+	Flags:  serverFlags,
+	CustomHelpTemplate: `NAME:
+   minio {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio {{.Name}} BACKEND [FLAGS]
+
+BACKEND:
+   fs      local POSIX filesystem.
+   s3      Amazon S3 or any S3 compatible endpoint.
+   gcs     Google Cloud Storage.
+   azure   Azure Blob Storage.
+
+ENVIRONMENT VARIABLES:
+   MINIO_GATEWAY: selects BACKEND when it is not given on the command line.
+
+{{if .VisibleFlags}}FLAGS:
+   {{range .VisibleFlags}}{{.}}
+   {{end}}{{end}}
+`,
+}
+
+// gatewayMain is the entry point for "minio gateway <backend>". It
+// mirrors serverMain but swaps the ObjectLayer backend used to satisfy
+// the S3 API instead of always serving the local filesystem.
+func gatewayMain(c *cli.Context) {
+	backend := c.Args().First()
+	if backend == "" {
+		backend = os.Getenv(gatewayEnvVar)
+	}
+	if backend == "" {
+		cli.ShowCommandHelpAndExit(c, "gateway", 1)
+	}
+
+	// The local filesystem driver is always initialized - the fs backend
+	// wraps it, and every backend reports its root path to the web API
+	// as webAPI.FSPath, regardless of which backend ends up serving
+	// object data.
+	filesystem, err := fs.New(mustGetFSPath())
+	fatalIf(err.Trace(backend), "Unable to initialize filesystem.", nil)
+
+	objectLayer, err := newObjectLayer(backend, filesystem)
+	fatalIf(err.Trace(backend), "Unable to initialize gateway backend '"+backend+"'.", nil)
+
+	serverHandler := configureServerHandler(objectLayer)
+
+	apiServer := NewServerMux(serverConfig.GetAddr(), serverHandler)
+	fatalIf(apiServer.ListenAndServe().Trace(), "Failed to start minio gateway.", nil)
+}
@@ -0,0 +1,329 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/probe"
+)
+
+// s3Gateway translates the S3 API exposed by storageAPI onto a remote
+// Amazon S3 (or any S3 compatible) endpoint using the minio-go client.
+// It is selected with "minio gateway s3" or MINIO_GATEWAY=s3.
+type s3Gateway struct {
+	// rootPath is only reported through GetRootPath (it becomes
+	// webAPI.FSPath), the object data itself always goes to client/core.
+	rootPath string
+	client   *minio.Client
+	// core exposes the low level S3 API (marker based ListObjects,
+	// multipart upload primitives) that the high level Client doesn't.
+	// Object and multipart operations below pass straight through to the
+	// backend, bucket policies are read back from policyDocs instead.
+	core *minio.Core
+
+	// policyMu guards policyDocs. The backend's own GetBucketPolicy only
+	// returns a coarse none/read-only/write-only/read-write enum, not
+	// the JSON document minio's bucket policy API deals in, so the
+	// document passed to SetBucketMetadata is cached here instead of
+	// being read back from the backend - it does not survive a process
+	// restart or get shared across multiple minio gateway instances.
+	policyMu   sync.RWMutex
+	policyDocs map[string]string
+}
+
+// newS3Gateway initializes a minio-go client pointed at Amazon S3 (or a
+// compatible endpoint) using the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_ENDPOINT environment variables.
+func newS3Gateway(filesystem fs.Filesystem) (ObjectLayer, *probe.Error) {
+	endpoint := os.Getenv("AWS_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+	client, e := minio.New(endpoint, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), true)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &s3Gateway{
+		rootPath:   filesystem.GetRootPath(),
+		client:     client,
+		core:       &minio.Core{Client: client},
+		policyDocs: make(map[string]string),
+	}, nil
+}
+
+// GetRootPath returns the local filesystem path handed to the web API as
+// webAPI.FSPath, it has no bearing on where object data is stored.
+func (s *s3Gateway) GetRootPath() string {
+	return s.rootPath
+}
+
+// ListBuckets translates to the equivalent ListBuckets call on the
+// backend S3 endpoint.
+func (s *s3Gateway) ListBuckets() ([]fs.BucketMetadata, *probe.Error) {
+	buckets, e := s.client.ListBuckets()
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	var metadata []fs.BucketMetadata
+	for _, b := range buckets {
+		metadata = append(metadata, fs.BucketMetadata{
+			Name:    b.Name,
+			Created: b.CreationDate,
+		})
+	}
+	return metadata, nil
+}
+
+// MakeBucket translates to the equivalent MakeBucket call on the backend
+// S3 endpoint.
+func (s *s3Gateway) MakeBucket(bucket string) *probe.Error {
+	if e := s.client.MakeBucket(bucket, ""); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// DeleteBucket translates to the equivalent RemoveBucket call on the
+// backend S3 endpoint.
+func (s *s3Gateway) DeleteBucket(bucket string) *probe.Error {
+	if e := s.client.RemoveBucket(bucket); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// GetBucketMetadata is not exposed by the S3 API itself, only the
+// bucket's existence and region are known, so report zero-value
+// metadata rather than guessing.
+func (s *s3Gateway) GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error) {
+	found, e := s.client.BucketExists(bucket)
+	if e != nil {
+		return fs.BucketMetadata{}, probe.NewError(e)
+	}
+	if !found {
+		return fs.BucketMetadata{}, probe.NewError(fs.BucketNotFound{Bucket: bucket})
+	}
+	return fs.BucketMetadata{Name: bucket}, nil
+}
+
+// SetBucketMetadata translates the "policy" key into the backend's own
+// PutBucketPolicy call - it is the only bucket-level setting the S3 API
+// itself exposes, anything else in metadata is silently ignored.
+func (s *s3Gateway) SetBucketMetadata(bucket string, metadata map[string]string) *probe.Error {
+	policyJSON, ok := metadata["policy"]
+	if !ok {
+		return nil
+	}
+	if e := s.client.SetBucketPolicy(bucket, "", policy.BucketPolicy(policyJSON)); e != nil {
+		return probe.NewError(e)
+	}
+	s.policyMu.Lock()
+	s.policyDocs[bucket] = policyJSON
+	s.policyMu.Unlock()
+	return nil
+}
+
+// GetBucketPolicy returns the document most recently passed to
+// SetBucketMetadata for bucket, see the policyDocs field comment.
+func (s *s3Gateway) GetBucketPolicy(bucket string) (string, *probe.Error) {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policyDocs[bucket], nil
+}
+
+// ListObjects translates to the backend's own marker based ListObjects,
+// so paging through a bucket larger than maxKeys works the same way it
+// does against the local filesystem driver.
+func (s *s3Gateway) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (fs.ListObjectsResult, *probe.Error) {
+	resp, e := s.core.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if e != nil {
+		return fs.ListObjectsResult{}, probe.NewError(e)
+	}
+	var result fs.ListObjectsResult
+	for _, object := range resp.Contents {
+		result.Objects = append(result.Objects, fs.ObjectMetadata{
+			Bucket:  bucket,
+			Object:  object.Key,
+			Size:    object.Size,
+			Md5:     object.ETag,
+			Created: object.LastModified,
+		})
+	}
+	result.IsTruncated = resp.IsTruncated
+	return result, nil
+}
+
+// GetObject streams object data directly from the backend S3 endpoint
+// into w, starting at startOffset.
+func (s *s3Gateway) GetObject(w io.Writer, bucket, object string, startOffset int64) (int64, *probe.Error) {
+	reader, e := s.client.GetObject(bucket, object)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	defer reader.Close()
+	if startOffset > 0 {
+		if _, e = reader.Seek(startOffset, 0); e != nil {
+			return 0, probe.NewError(e)
+		}
+	}
+	n, e := io.Copy(w, reader)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	return n, nil
+}
+
+// GetObjectMetadata translates to the equivalent StatObject call on the
+// backend S3 endpoint.
+func (s *s3Gateway) GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error) {
+	info, e := s.client.StatObject(bucket, object)
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{
+		Bucket:  bucket,
+		Object:  object,
+		Size:    info.Size,
+		Md5:     info.ETag,
+		Created: info.LastModified,
+	}, nil
+}
+
+// CreateObject streams data up to the backend S3 endpoint via PutObject.
+func (s *s3Gateway) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	n, e := s.client.PutObject(bucket, object, data, "application/octet-stream")
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return fs.ObjectMetadata{
+		Bucket: bucket,
+		Object: object,
+		Size:   n,
+	}, nil
+}
+
+// DeleteObject translates to the equivalent RemoveObject call on the
+// backend S3 endpoint.
+func (s *s3Gateway) DeleteObject(bucket, object string) *probe.Error {
+	if e := s.client.RemoveObject(bucket, object); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// CopyObject uses the backend's own native server side copy instead of
+// streaming the object through this process.
+func (s *s3Gateway) CopyObject(bucket, object, sourceBucket, sourceObject string, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	src := minio.NewSourceInfo(sourceBucket, sourceObject, nil)
+	dst, e := minio.NewDestinationInfo(bucket, object, nil, metadata)
+	if e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	if e = s.client.CopyObject(dst, src); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return s.GetObjectMetadata(bucket, object)
+}
+
+// ListMultipartUploads translates to the backend's own ListMultipartUploads.
+func (s *s3Gateway) ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error) {
+	resp, e := s.core.ListMultipartUploads(bucket, resources.Prefix, resources.KeyMarker, resources.UploadIDMarker, resources.Delimiter, resources.MaxUploads)
+	if e != nil {
+		return resources, probe.NewError(e)
+	}
+	resources.IsTruncated = resp.IsTruncated
+	resources.NextKeyMarker = resp.NextKeyMarker
+	resources.NextUploadIDMarker = resp.NextUploadIDMarker
+	for _, u := range resp.Uploads {
+		resources.Uploads = append(resources.Uploads, fs.UploadMetadata{
+			Key:       u.Key,
+			UploadID:  u.UploadID,
+			Initiated: u.Initiated,
+		})
+	}
+	return resources, nil
+}
+
+// NewMultipartUpload translates to the backend's own NewMultipartUpload,
+// the returned upload ID is the backend's own - it is never interpreted
+// locally, only ever handed back to the backend on later calls.
+func (s *s3Gateway) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	uploadID, e := s.core.NewMultipartUpload(bucket, object, minio.PutObjectOptions{})
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+	return uploadID, nil
+}
+
+// PutObjectPart translates to the backend's own PutObjectPart.
+func (s *s3Gateway) PutObjectPart(bucket, object, uploadID string, partNumber int, expectedMD5Sum string, size int64, data io.Reader) (fs.PartMetadata, *probe.Error) {
+	part, e := s.core.PutObjectPart(bucket, object, uploadID, partNumber, data, size, expectedMD5Sum, "")
+	if e != nil {
+		return fs.PartMetadata{}, probe.NewError(e)
+	}
+	return fs.PartMetadata{
+		PartNumber: part.PartNumber,
+		ETag:       part.ETag,
+		Size:       part.Size,
+	}, nil
+}
+
+// ListObjectParts translates to the backend's own ListObjectParts.
+func (s *s3Gateway) ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error) {
+	resp, e := s.core.ListObjectParts(bucket, object, resources.UploadID, resources.PartNumberMarker, resources.MaxParts)
+	if e != nil {
+		return resources, probe.NewError(e)
+	}
+	resources.IsTruncated = resp.IsTruncated
+	resources.NextPartNumberMarker = resp.NextPartNumberMarker
+	for _, p := range resp.ObjectParts {
+		resources.Parts = append(resources.Parts, fs.PartMetadata{
+			PartNumber: p.PartNumber,
+			ETag:       p.ETag,
+			Size:       p.Size,
+		})
+	}
+	return resources, nil
+}
+
+// CompleteMultipartUpload translates to the backend's own
+// CompleteMultipartUpload.
+func (s *s3Gateway) CompleteMultipartUpload(bucket, object, uploadID string, parts map[int]string) (fs.ObjectMetadata, *probe.Error) {
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for partNumber, etag := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: partNumber, ETag: etag})
+	}
+	if _, e := s.core.CompleteMultipartUpload(bucket, object, uploadID, completeParts); e != nil {
+		return fs.ObjectMetadata{}, probe.NewError(e)
+	}
+	return s.GetObjectMetadata(bucket, object)
+}
+
+// AbortMultipartUpload translates to the backend's own
+// AbortMultipartUpload.
+func (s *s3Gateway) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	if e := s.core.AbortMultipartUpload(bucket, object, uploadID); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/notify"
+)
+
+// notifyingResponseWriter records the status code and body a wrapped
+// handler wrote, so notifyObjectEvent can skip emitting an event for a
+// request that actually failed, and notifyDeleteMultipleObjectsEvent
+// can see which keys a mixed-result multi-delete actually removed.
+type notifyingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *notifyingResponseWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *notifyingResponseWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// notifyObjectEvent wraps an object operation handler so that, on
+// success, it emits eventName through notifier for the bucket/object
+// named by the request's route variables. Handlers themselves stay
+// backend-agnostic - this is the one place PutObject, CopyObject,
+// CompleteMultipartUpload and DeleteObject become visible to
+// subscribers, so hooking in here (rather than inside each handler)
+// keeps every sink wired up the same way regardless of the handler's
+// own success/error plumbing.
+func notifyObjectEvent(notifier *notify.Notifier, eventName notify.EventName, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nw := &notifyingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(nw, r)
+		if nw.statusCode >= 300 {
+			return
+		}
+		vars := router.Vars(r)
+		notifier.Notify(notify.Event{
+			EventName:  eventName,
+			BucketName: vars["bucket"],
+			ObjectName: vars["object"],
+		})
+	}
+}
+
+// notifyPostPolicyEvent wraps PostPolicyBucketHandler. The uploaded
+// object's key lives in the multipart form body rather than a route
+// variable, so it's read here after next returns, from the form next
+// parsed and net/http cached on the request as r.MultipartForm - the
+// form is not parsed here.
+func notifyPostPolicyEvent(notifier *notify.Notifier, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nw := &notifyingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(nw, r)
+		if nw.statusCode >= 300 {
+			return
+		}
+		key := r.MultipartForm.Value["key"]
+		if len(key) == 0 {
+			return
+		}
+		notifier.Notify(notify.Event{
+			EventName:  notify.ObjectCreatedPost,
+			BucketName: router.Vars(r)["bucket"],
+			ObjectName: key[0],
+		})
+	}
+}
+
+// deleteObjectsResult mirrors the <DeleteResult> body
+// DeleteMultipleObjectsHandler writes back. A 200 response can still
+// list some keys under Error rather than Deleted, so the response body
+// - not the request body or the HTTP status alone - is the only
+// reliable record of which keys were actually removed.
+type deleteObjectsResult struct {
+	XMLName xml.Name `xml:"DeleteResult"`
+	Deleted []struct {
+		Key string `xml:"Key"`
+	} `xml:"Deleted"`
+}
+
+// notifyDeleteMultipleObjectsEvent wraps DeleteMultipleObjectsHandler.
+// The deleted keys live in the response body rather than a route
+// variable, so the request body is read and restored here (so next
+// still sees the full request it expects), and the response body next
+// writes is captured through notifyingResponseWriter so only the keys
+// it actually reports as deleted get an event.
+func notifyDeleteMultipleObjectsEvent(notifier *notify.Notifier, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		nw := &notifyingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(nw, r)
+		if nw.statusCode >= 300 {
+			return
+		}
+
+		var result deleteObjectsResult
+		if err = xml.Unmarshal(nw.body.Bytes(), &result); err != nil {
+			return
+		}
+		bucket := router.Vars(r)["bucket"]
+		for _, object := range result.Deleted {
+			notifier.Notify(notify.Event{
+				EventName:  notify.ObjectRemovedDelete,
+				BucketName: bucket,
+				ObjectName: object.Key,
+			})
+		}
+	}
+}
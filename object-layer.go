@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/probe"
+)
+
+// ObjectLayer abstracts away the storage backend used to satisfy the S3
+// compatible API exposed by storageAPI. It is implemented by the local
+// POSIX filesystem driver as well as gateway drivers that translate calls
+// onto a remote object store (Amazon S3, Google Cloud Storage, Azure Blob).
+//
+// Handlers never talk to a backend directly, they only ever go through
+// this interface - that keeps Put/Get/Head/Copy/DeleteObject and friends
+// identical regardless of which backend is serving the request.
+type ObjectLayer interface {
+	// Storage service operations.
+	GetRootPath() string
+
+	// Bucket operations.
+	ListBuckets() ([]fs.BucketMetadata, *probe.Error)
+	MakeBucket(bucket string) *probe.Error
+	GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error)
+	SetBucketMetadata(bucket string, metadata map[string]string) *probe.Error
+	// GetBucketPolicy returns the raw policy document most recently set
+	// through SetBucketMetadata's "policy" key, or "" if none was set.
+	GetBucketPolicy(bucket string) (string, *probe.Error)
+	DeleteBucket(bucket string) *probe.Error
+	ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (fs.ListObjectsResult, *probe.Error)
+
+	// Object operations.
+	GetObject(w io.Writer, bucket, object string, startOffset int64) (int64, *probe.Error)
+	GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error)
+	CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string) (fs.ObjectMetadata, *probe.Error)
+	DeleteObject(bucket, object string) *probe.Error
+	CopyObject(bucket, object, sourceBucket, sourceObject string, metadata map[string]string) (fs.ObjectMetadata, *probe.Error)
+
+	// Multipart operations.
+	ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error)
+	NewMultipartUpload(bucket, object string) (string, *probe.Error)
+	PutObjectPart(bucket, object, uploadID string, partNumber int, expectedMD5Sum string, size int64, data io.Reader) (fs.PartMetadata, *probe.Error)
+	ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error)
+	CompleteMultipartUpload(bucket, object, uploadID string, parts map[int]string) (fs.ObjectMetadata, *probe.Error)
+	AbortMultipartUpload(bucket, object, uploadID string) *probe.Error
+}
+
+// gatewayBackend identifies a pluggable ObjectLayer implementation that
+// can be selected through the "minio gateway <backend>" sub-command or
+// the MINIO_GATEWAY environment variable.
+type gatewayBackend string
+
+const (
+	gatewayBackendFS    gatewayBackend = "fs"
+	gatewayBackendS3    gatewayBackend = "s3"
+	gatewayBackendGCS   gatewayBackend = "gcs"
+	gatewayBackendAzure gatewayBackend = "azure"
+)
+
+// gatewayEnvVar is the environment variable consulted by newObjectLayer
+// when no explicit backend is requested on the command line, e.g.
+// MINIO_GATEWAY=s3|gcs|azure|fs.
+const gatewayEnvVar = "MINIO_GATEWAY"
+
+// newObjectLayer constructs the ObjectLayer backend named by backend,
+// falling back to the MINIO_GATEWAY environment variable and finally to
+// the local filesystem driver when neither is set. filesystem is always
+// initialized by the caller since the "fs" backend wraps it and every
+// backend reports its root path (webAPI.FSPath) regardless of which
+// backend ends up handling S3 object operations.
+func newObjectLayer(backend string, filesystem fs.Filesystem) (ObjectLayer, *probe.Error) {
+	if backend == "" {
+		backend = os.Getenv(gatewayEnvVar)
+	}
+	switch gatewayBackend(strings.ToLower(backend)) {
+	case gatewayBackendS3:
+		return newS3Gateway(filesystem)
+	case gatewayBackendGCS:
+		return newGCSGateway(filesystem)
+	case gatewayBackendAzure:
+		return newAzureGateway(filesystem)
+	case gatewayBackendFS, "":
+		return newFSGateway(filesystem), nil
+	default:
+		return nil, probe.NewError(fmt.Errorf("unknown gateway backend '%s'", backend))
+	}
+}
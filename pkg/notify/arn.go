@@ -0,0 +1,63 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// arnPrefix identifies every sink ARN minio accepts inside a
+// NotificationConfiguration document: arn:minio:sqs::<id>:<service>.
+const arnPrefix = "arn:minio:sqs:"
+
+// service extracts the sink kind (the final ":service" segment) from an
+// ARN such as "arn:minio:sqs::1:webhook".
+func service(arn string) (string, error) {
+	if !strings.HasPrefix(arn, arnPrefix) {
+		return "", fmt.Errorf("notify: %q is not a recognized minio sink ARN", arn)
+	}
+	parts := strings.Split(strings.TrimPrefix(arn, arnPrefix), ":")
+	if len(parts) == 0 || parts[len(parts)-1] == "" {
+		return "", fmt.Errorf("notify: %q does not name a sink type", arn)
+	}
+	return parts[len(parts)-1], nil
+}
+
+// newTargetFromARN is the default Target constructor used by Notifier,
+// each sink reads its own connection settings from the environment so
+// operators configure credentials the same way the gateway backends do.
+func newTargetFromARN(arn string) (Target, error) {
+	kind, err := service(arn)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "amqp":
+		return newAMQPTarget()
+	case "nats":
+		return newNATSTarget()
+	case "elasticsearch":
+		return newElasticsearchTarget()
+	case "redis":
+		return newRedisTarget()
+	case "webhook":
+		return newWebhookTarget(arn)
+	default:
+		return nil, fmt.Errorf("notify: unsupported sink type %q in ARN %q", kind, arn)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import "strings"
+
+// FilterRule narrows a subscription down to keys matching a prefix
+// and/or suffix, mirroring the S3 NotificationConfiguration schema.
+type FilterRule struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+// Filter wraps the S3Key/FilterRule nesting used by the XML schema.
+type Filter struct {
+	S3Key struct {
+		FilterRules []FilterRule `xml:"FilterRule"`
+	} `xml:"S3Key"`
+}
+
+// matches reports whether object satisfies every configured prefix and
+// suffix rule in f.
+func (f Filter) matches(object string) bool {
+	for _, rule := range f.S3Key.FilterRules {
+		switch strings.ToLower(rule.Name) {
+		case "prefix":
+			if !strings.HasPrefix(object, rule.Value) {
+				return false
+			}
+		case "suffix":
+			if !strings.HasSuffix(object, rule.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// topicConfig is shared by TopicConfiguration, QueueConfiguration and
+// CloudFunctionConfiguration - the three ARN-identified sink kinds the
+// S3 schema supports all carry the same Id/Event/Filter fields and
+// differ only in which ARN attribute names the sink.
+type topicConfig struct {
+	ID     string   `xml:"Id"`
+	Events []string `xml:"Event"`
+	Filter Filter   `xml:"Filter"`
+}
+
+func (t topicConfig) matches(event Event) bool {
+	if !t.Filter.matches(event.ObjectName) {
+		return false
+	}
+	for _, e := range t.Events {
+		if eventNameMatches(EventName(e), event.EventName) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventNameMatches allows a configured event such as
+// "s3:ObjectCreated:*" to match any concrete ObjectCreated EventName.
+func eventNameMatches(configured, actual EventName) bool {
+	if configured == actual {
+		return true
+	}
+	if strings.HasSuffix(string(configured), ":*") {
+		return strings.HasPrefix(string(actual), strings.TrimSuffix(string(configured), "*"))
+	}
+	return false
+}
+
+// TopicConfiguration routes events to the sink its Topic ARN names,
+// typically AMQP or NATS - the sink type is not restricted here.
+type TopicConfiguration struct {
+	topicConfig
+	Topic string `xml:"Topic"`
+}
+
+// QueueConfiguration routes events to the sink its Queue ARN names,
+// typically Redis or Elasticsearch - the sink type is not restricted
+// here.
+type QueueConfiguration struct {
+	topicConfig
+	Queue string `xml:"Queue"`
+}
+
+// CloudFunctionConfiguration routes events to the sink its
+// CloudFunction ARN names, typically a webhook - the sink type is not
+// restricted here.
+type CloudFunctionConfiguration struct {
+	topicConfig
+	CloudFunction string `xml:"CloudFunction"`
+}
+
+// BucketNotificationConfig is the parsed form of the
+// NotificationConfiguration XML document accepted by
+// PUT ?notification.
+type BucketNotificationConfig struct {
+	XMLName                     struct{}                     `xml:"NotificationConfiguration"`
+	TopicConfigurations         []TopicConfiguration         `xml:"TopicConfiguration"`
+	QueueConfigurations         []QueueConfiguration         `xml:"QueueConfiguration"`
+	CloudFunctionConfigurations []CloudFunctionConfiguration `xml:"CloudFunctionConfiguration"`
+}
+
+// arns returns every sink ARN referenced anywhere in the configuration.
+func (b BucketNotificationConfig) arns() []string {
+	var arns []string
+	for _, c := range b.TopicConfigurations {
+		arns = append(arns, c.Topic)
+	}
+	for _, c := range b.QueueConfigurations {
+		arns = append(arns, c.Queue)
+	}
+	for _, c := range b.CloudFunctionConfigurations {
+		arns = append(arns, c.CloudFunction)
+	}
+	return arns
+}
+
+// matchingARNs returns the ARNs of every sink subscribed to event,
+// after applying each configuration's event type and prefix/suffix
+// filters.
+func (b BucketNotificationConfig) matchingARNs(event Event) []string {
+	var arns []string
+	for _, c := range b.TopicConfigurations {
+		if c.matches(event) {
+			arns = append(arns, c.Topic)
+		}
+	}
+	for _, c := range b.QueueConfigurations {
+		if c.matches(event) {
+			arns = append(arns, c.Queue)
+		}
+	}
+	for _, c := range b.CloudFunctionConfigurations {
+		if c.matches(event) {
+			arns = append(arns, c.CloudFunction)
+		}
+	}
+	return arns
+}
@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package notify implements S3 compatible bucket event notifications.
+// Object mutations are translated into S3 event records and fanned out
+// asynchronously to pluggable sinks (AMQP, NATS, Elasticsearch, Redis,
+// webhooks) configured per bucket through the standard
+// NotificationConfiguration XML document.
+package notify
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// EventName identifies the kind of S3 event that occurred, it matches
+// the "Event" values accepted inside NotificationConfiguration
+// (s3:ObjectCreated:Put, s3:ObjectRemoved:Delete, ...).
+type EventName string
+
+// Supported event names, a subset of the S3 event notification types
+// relevant to the object mutations minio exposes.
+const (
+	ObjectCreatedPut                     EventName = "s3:ObjectCreated:Put"
+	ObjectCreatedPost                    EventName = "s3:ObjectCreated:Post"
+	ObjectCreatedCopy                    EventName = "s3:ObjectCreated:Copy"
+	ObjectCreatedCompleteMultipartUpload EventName = "s3:ObjectCreated:CompleteMultipartUpload"
+	ObjectRemovedDelete                  EventName = "s3:ObjectRemoved:Delete"
+)
+
+// Event is the record emitted for every object mutation, it mirrors the
+// "Records[]" entries of the S3 event notification JSON payload closely
+// enough for sinks to consume without a minio specific schema.
+type Event struct {
+	EventName  EventName `json:"eventName"`
+	EventTime  time.Time `json:"eventTime"`
+	BucketName string    `json:"bucketName"`
+	ObjectName string    `json:"objectName"`
+	Size       int64     `json:"size,omitempty"`
+	ETag       string    `json:"eTag,omitempty"`
+}
+
+// Target is a single event sink. Send must not block the caller for
+// long - the Notifier already runs each Target on its own bounded queue,
+// but a Target that blocks forever can still starve its own queue.
+type Target interface {
+	Send(event Event) error
+	Close() error
+}
+
+// queueDepth bounds how many pending events a single Target's queue may
+// hold before new events are dropped and logged rather than blocking
+// the object handler that produced them.
+const queueDepth = 4096
+
+// sinkQueue pairs a Target with its own buffered channel and worker
+// goroutine, so one slow subscriber can never delay another.
+type sinkQueue struct {
+	arn    string
+	target Target
+	events chan Event
+}
+
+// Notifier fans out bucket events to the sinks configured for each
+// bucket. Object handlers call Notify after a mutation succeeds, the
+// actual delivery to AMQP/NATS/Elasticsearch/Redis/webhook endpoints
+// happens on background goroutines so hot paths are never blocked by a
+// slow or unreachable subscriber.
+type Notifier struct {
+	mutex sync.RWMutex
+	// configs holds the parsed NotificationConfiguration per bucket.
+	configs map[string]BucketNotificationConfig
+	// queues holds the running sinkQueue per ARN, shared across buckets
+	// that point at the same sink.
+	queues map[string]*sinkQueue
+	// newTarget constructs a Target for a given ARN, overridable in
+	// tests.
+	newTarget func(arn string) (Target, error)
+}
+
+// NewNotifier creates an empty Notifier, buckets opt in to notifications
+// through SetBucketConfig.
+func NewNotifier() *Notifier {
+	return &Notifier{
+		configs:   make(map[string]BucketNotificationConfig),
+		queues:    make(map[string]*sinkQueue),
+		newTarget: newTargetFromARN,
+	}
+}
+
+// SetBucketConfig replaces the notification configuration for bucket,
+// starting a sinkQueue for any ARN that isn't already running one.
+// Every new target is dialed before the Notifier is touched at all, so
+// a slow or unreachable sink never holds up Notify calls for other
+// buckets, and a config naming several ARNs either starts all of them
+// or none - it never leaves an earlier ARN's connection running after a
+// later one in the same request fails.
+func (n *Notifier) SetBucketConfig(bucket string, config BucketNotificationConfig) error {
+	n.mutex.RLock()
+	var toStart []string
+	for _, arn := range config.arns() {
+		if _, ok := n.queues[arn]; !ok {
+			toStart = append(toStart, arn)
+		}
+	}
+	n.mutex.RUnlock()
+
+	newQueues := make(map[string]*sinkQueue, len(toStart))
+	for _, arn := range toStart {
+		target, err := n.newTarget(arn)
+		if err != nil {
+			for _, q := range newQueues {
+				q.target.Close()
+			}
+			return err
+		}
+		newQueues[arn] = &sinkQueue{arn: arn, target: target, events: make(chan Event, queueDepth)}
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for arn, q := range newQueues {
+		// Another SetBucketConfig call may have started this ARN's
+		// queue while we were dialing, keep the one already running.
+		if _, ok := n.queues[arn]; ok {
+			q.target.Close()
+			continue
+		}
+		go q.run()
+		n.queues[arn] = q
+	}
+	n.configs[bucket] = config
+	return nil
+}
+
+// GetBucketConfig returns the notification configuration previously set
+// for bucket, it is the zero value BucketNotificationConfig if the
+// bucket has none.
+func (n *Notifier) GetBucketConfig(bucket string) BucketNotificationConfig {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.configs[bucket]
+}
+
+// DeleteBucketConfig removes any notification configuration set for
+// bucket. The underlying sink queues are left running since other
+// buckets may still reference them.
+func (n *Notifier) DeleteBucketConfig(bucket string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	delete(n.configs, bucket)
+}
+
+// Notify fans event out to every sink configured for event.BucketName
+// that subscribes to event.EventName. It never blocks on a slow sink -
+// queues that are full drop the event and log the overflow.
+func (n *Notifier) Notify(event Event) {
+	n.mutex.RLock()
+	config, ok := n.configs[event.BucketName]
+	n.mutex.RUnlock()
+	if !ok {
+		return
+	}
+	for _, arn := range config.matchingARNs(event) {
+		n.mutex.RLock()
+		q, ok := n.queues[arn]
+		n.mutex.RUnlock()
+		if !ok {
+			continue
+		}
+		select {
+		case q.events <- event:
+		default:
+			log.Printf("notify: queue full for sink %s, dropping %s event for %s/%s", arn, event.EventName, event.BucketName, event.ObjectName)
+		}
+	}
+}
+
+// run delivers queued events to the sink one at a time, logging and
+// continuing on delivery errors so one bad event can't wedge the queue.
+func (q *sinkQueue) run() {
+	for event := range q.events {
+		if err := q.target.Send(event); err != nil {
+			log.Printf("notify: delivering event to sink %s failed: %v", q.arn, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// fakeTarget records every event handed to Send and lets tests block
+// delivery until they're ready to observe the queue. When block is
+// non-nil, Send reports entry on entered (if set) and then waits for
+// block to be closed before recording the event, so a test can pin the
+// sinkQueue's worker goroutine mid-delivery.
+type fakeTarget struct {
+	mu      sync.Mutex
+	events  []Event
+	closed  bool
+	block   chan struct{}
+	entered chan struct{}
+}
+
+func (t *fakeTarget) Send(event Event) error {
+	if t.entered != nil {
+		t.entered <- struct{}{}
+	}
+	if t.block != nil {
+		<-t.block
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+	return nil
+}
+
+func (t *fakeTarget) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	return nil
+}
+
+func newTestNotifier(newTarget func(arn string) (Target, error)) *Notifier {
+	n := NewNotifier()
+	n.newTarget = newTarget
+	return n
+}
+
+func TestSetBucketConfigStartsEachARNOnce(t *testing.T) {
+	built := map[string]*fakeTarget{}
+	builds := 0
+	var mu sync.Mutex
+	n := newTestNotifier(func(arn string) (Target, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		target := &fakeTarget{}
+		built[arn] = target
+		builds++
+		return target, nil
+	})
+
+	config := BucketNotificationConfig{
+		QueueConfigurations: []QueueConfiguration{{Queue: "arn:minio:sqs::1:redis"}},
+	}
+	if err := n.SetBucketConfig("bucket-a", config); err != nil {
+		t.Fatalf("SetBucketConfig: %v", err)
+	}
+	if err := n.SetBucketConfig("bucket-b", config); err != nil {
+		t.Fatalf("SetBucketConfig: %v", err)
+	}
+	if builds != 1 || len(built) != 1 {
+		t.Fatalf("expected the shared ARN's target to be built once, built %d times", builds)
+	}
+}
+
+func TestSetBucketConfigFailsAtomically(t *testing.T) {
+	n := newTestNotifier(func(arn string) (Target, error) {
+		if arn == "arn:minio:sqs::2:webhook" {
+			return nil, fmt.Errorf("dial failed")
+		}
+		return &fakeTarget{}, nil
+	})
+
+	config := BucketNotificationConfig{
+		TopicConfigurations: []TopicConfiguration{{Topic: "arn:minio:sqs::1:redis"}},
+		QueueConfigurations: []QueueConfiguration{{Queue: "arn:minio:sqs::2:webhook"}},
+	}
+	if err := n.SetBucketConfig("bucket-a", config); err == nil {
+		t.Fatal("expected SetBucketConfig to fail when one of two ARNs can't be dialed")
+	}
+	if _, ok := n.queues["arn:minio:sqs::1:redis"]; ok {
+		t.Fatal("the ARN that dialed successfully should not be left running after a sibling ARN failed")
+	}
+	if len(n.configs) != 0 {
+		t.Fatal("a failed SetBucketConfig must not record a partial configuration")
+	}
+}
+
+func TestNotifyDropsEventsOnFullQueue(t *testing.T) {
+	target := &fakeTarget{block: make(chan struct{}), entered: make(chan struct{})}
+	defer close(target.block)
+	n := newTestNotifier(func(arn string) (Target, error) { return target, nil })
+
+	// The configuration must subscribe to the event Notify is called with
+	// below, otherwise Notify never reaches the full queue at all.
+	config := BucketNotificationConfig{
+		QueueConfigurations: []QueueConfiguration{{
+			topicConfig: topicConfig{Events: []string{string(ObjectCreatedPut)}},
+			Queue:       "arn:minio:sqs::1:redis",
+		}},
+	}
+	if err := n.SetBucketConfig("bucket-a", config); err != nil {
+		t.Fatalf("SetBucketConfig: %v", err)
+	}
+
+	n.mutex.RLock()
+	q := n.queues["arn:minio:sqs::1:redis"]
+	n.mutex.RUnlock()
+
+	// Hand the worker goroutine one event and wait for it to enter
+	// Send, where it blocks - that pins the worker so it can't drain
+	// anything else, guaranteeing the queue we fill next stays full
+	// until Notify is called.
+	q.events <- Event{}
+	<-target.entered
+
+	for i := 0; i < queueDepth; i++ {
+		q.events <- Event{}
+	}
+	done := make(chan struct{})
+	go func() {
+		n.Notify(Event{BucketName: "bucket-a", EventName: ObjectCreatedPut})
+		close(done)
+	}()
+	<-done
+	if len(q.events) != queueDepth {
+		t.Fatalf("expected the overflow event to be dropped, queue len = %d, want %d", len(q.events), queueDepth)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/streadway/amqp"
+)
+
+// amqpTarget publishes events as JSON messages to a durable exchange,
+// configured through the standard AMQP_URL and AMQP_EXCHANGE
+// environment variables.
+type amqpTarget struct {
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	exchange string
+}
+
+func newAMQPTarget() (Target, error) {
+	url := os.Getenv("AMQP_URL")
+	if url == "" {
+		return nil, fmt.Errorf("notify: AMQP_URL is not set")
+	}
+	exchange := os.Getenv("AMQP_EXCHANGE")
+	if exchange == "" {
+		exchange = "minio-events"
+	}
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = channel.ExchangeDeclare(exchange, "fanout", true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, err
+	}
+	return &amqpTarget{conn: conn, channel: channel, exchange: exchange}, nil
+}
+
+func (t *amqpTarget) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return t.channel.Publish(t.exchange, "", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+	})
+}
+
+func (t *amqpTarget) Close() error {
+	t.channel.Close()
+	return t.conn.Close()
+}
@@ -0,0 +1,58 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/net/context"
+	elastic "gopkg.in/olivere/elastic.v3"
+)
+
+// elasticsearchTarget indexes every event as a document, configured
+// through the standard ELASTICSEARCH_URL and ELASTICSEARCH_INDEX
+// environment variables.
+type elasticsearchTarget struct {
+	client *elastic.Client
+	index  string
+}
+
+func newElasticsearchTarget() (Target, error) {
+	url := os.Getenv("ELASTICSEARCH_URL")
+	if url == "" {
+		return nil, fmt.Errorf("notify: ELASTICSEARCH_URL is not set")
+	}
+	index := os.Getenv("ELASTICSEARCH_INDEX")
+	if index == "" {
+		index = "minio-events"
+	}
+	client, err := elastic.NewClient(elastic.SetURL(url), elastic.SetSniff(false))
+	if err != nil {
+		return nil, err
+	}
+	return &elasticsearchTarget{client: client, index: index}, nil
+}
+
+func (t *elasticsearchTarget) Send(event Event) error {
+	_, err := t.client.Index().Index(t.index).Type("event").BodyJson(event).Do(context.Background())
+	return err
+}
+
+func (t *elasticsearchTarget) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nats-io/nats"
+)
+
+// natsTarget publishes events as JSON messages to a subject, configured
+// through the standard NATS_URL and NATS_SUBJECT environment
+// variables.
+type natsTarget struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func newNATSTarget() (Target, error) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		return nil, fmt.Errorf("notify: NATS_URL is not set")
+	}
+	subject := os.Getenv("NATS_SUBJECT")
+	if subject == "" {
+		subject = "minio-events"
+	}
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	return &natsTarget{conn: conn, subject: subject}, nil
+}
+
+func (t *natsTarget) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return t.conn.Publish(t.subject, body)
+}
+
+func (t *natsTarget) Close() error {
+	t.conn.Close()
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// redisTarget pushes events onto a list, configured through the
+// standard REDIS_ADDR and REDIS_KEY environment variables.
+type redisTarget struct {
+	pool *redis.Pool
+	key  string
+}
+
+func newRedisTarget() (Target, error) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("notify: REDIS_ADDR is not set")
+	}
+	key := os.Getenv("REDIS_KEY")
+	if key == "" {
+		key = "minio-events"
+	}
+	pool := &redis.Pool{
+		MaxIdle: 4,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+	return &redisTarget{pool: pool, key: key}, nil
+}
+
+func (t *redisTarget) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	conn := t.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("RPUSH", t.key, body)
+	return err
+}
+
+func (t *redisTarget) Close() error {
+	return t.pool.Close()
+}
@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// webhookTarget POSTs every event as a JSON body to an endpoint URL,
+// configured through the WEBHOOK_ENDPOINT environment variable (one
+// endpoint per ARN, e.g. WEBHOOK_ENDPOINT_<sanitized-arn>, falling back
+// to WEBHOOK_ENDPOINT).
+type webhookTarget struct {
+	endpoint string
+	client   *http.Client
+}
+
+// envSafe replaces every character illegal in a shell/systemd
+// environment variable name (an ARN is full of ':' and '/') with '_',
+// since "export FOO:BAR=baz" is a syntax error in every shell an
+// operator would actually set this with.
+var envUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func envSafe(arn string) string {
+	return envUnsafeChars.ReplaceAllString(arn, "_")
+}
+
+func newWebhookTarget(arn string) (Target, error) {
+	endpoint := os.Getenv("WEBHOOK_ENDPOINT_" + envSafe(arn))
+	if endpoint == "" {
+		endpoint = os.Getenv("WEBHOOK_ENDPOINT")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("notify: WEBHOOK_ENDPOINT is not set for %q", arn)
+	}
+	return &webhookTarget{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (t *webhookTarget) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notify: webhook %s responded with status %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+func (t *webhookTarget) Close() error {
+	return nil
+}
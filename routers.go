@@ -27,7 +27,7 @@ import (
 	jsonrpc "github.com/gorilla/rpc/v2"
 	"github.com/gorilla/rpc/v2/json2"
 	"github.com/minio/minio-go"
-	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/notify"
 	"github.com/minio/minio/pkg/probe"
 	"github.com/minio/minio/pkg/s3/signature4"
 	"github.com/minio/miniobrowser"
@@ -35,10 +35,15 @@ import (
 
 // storageAPI container for S3 compatible API.
 type storageAPI struct {
-	// Filesystem instance.
-	Filesystem fs.Filesystem
+	// Filesystem instance, can be the local POSIX driver or a gateway
+	// backend (S3, GCS, Azure) selected by "minio gateway <backend>" or
+	// MINIO_GATEWAY, see object-layer.go.
+	Filesystem ObjectLayer
 	// Signature instance.
 	Signature *signature4.Sign
+	// Notifier fans out bucket events to the sinks configured per
+	// bucket through PUT/GET/DELETE ?notification, see pkg/notify.
+	Notifier *notify.Notifier
 }
 
 // webAPI container for Web API.
@@ -47,6 +52,11 @@ type webAPI struct {
 	FSPath string
 	// Minio client instance.
 	Client *minio.Client
+	// Filesystem instance, consulted before minting a presigned URL to
+	// confirm the bucket exists and that its policy doesn't explicitly
+	// deny the action being presigned, see validatePresignArgs in
+	// web-handlers.go.
+	Filesystem ObjectLayer
 
 	// private params.
 	apiAddress string // api destination address.
@@ -113,7 +123,7 @@ func registerAPIHandlers(mux *router.Router, a storageAPI, w *webAPI) {
 	// ListObjectPxarts
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
 	// CompleteMultipartUpload
-	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(notifyObjectEvent(a.Notifier, notify.ObjectCreatedCompleteMultipartUpload, a.CompleteMultipartUploadHandler)).Queries("uploadId", "{uploadId:.*}")
 	// NewMultipartUpload
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.NewMultipartUploadHandler).Queries("uploads", "")
 	// AbortMultipartUpload
@@ -121,11 +131,11 @@ func registerAPIHandlers(mux *router.Router, a storageAPI, w *webAPI) {
 	// GetObject
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.GetObjectHandler)
 	// CopyObject
-	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(a.CopyObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(notifyObjectEvent(a.Notifier, notify.ObjectCreatedCopy, a.CopyObjectHandler))
 	// PutObject
-	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(notifyObjectEvent(a.Notifier, notify.ObjectCreatedPut, a.PutObjectHandler))
 	// DeleteObject
-	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.DeleteObjectHandler)
+	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(notifyObjectEvent(a.Notifier, notify.ObjectRemovedDelete, a.DeleteObjectHandler))
 
 	/// Bucket operations
 
@@ -133,22 +143,28 @@ func registerAPIHandlers(mux *router.Router, a storageAPI, w *webAPI) {
 	bucket.Methods("GET").HandlerFunc(a.GetBucketLocationHandler).Queries("location", "")
 	// GetBucketPolicy
 	bucket.Methods("GET").HandlerFunc(a.GetBucketPolicyHandler).Queries("policy", "")
+	// GetBucketNotification
+	bucket.Methods("GET").HandlerFunc(a.GetBucketNotificationHandler).Queries("notification", "")
 	// ListMultipartUploads
 	bucket.Methods("GET").HandlerFunc(a.ListMultipartUploadsHandler).Queries("uploads", "")
 	// ListObjects
 	bucket.Methods("GET").HandlerFunc(a.ListObjectsHandler)
 	// PutBucketPolicy
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketPolicyHandler).Queries("policy", "")
+	// PutBucketNotification
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketNotificationHandler).Queries("notification", "")
 	// PutBucket
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketHandler)
 	// HeadBucket
 	bucket.Methods("HEAD").HandlerFunc(a.HeadBucketHandler)
 	// PostPolicy
-	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(a.PostPolicyBucketHandler)
+	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(notifyPostPolicyEvent(a.Notifier, a.PostPolicyBucketHandler))
 	// DeleteMultipleObjects
-	bucket.Methods("POST").HandlerFunc(a.DeleteMultipleObjectsHandler)
+	bucket.Methods("POST").HandlerFunc(notifyDeleteMultipleObjectsEvent(a.Notifier, a.DeleteMultipleObjectsHandler))
 	// DeleteBucketPolicy
 	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketPolicyHandler).Queries("policy", "")
+	// DeleteBucketNotification
+	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketNotificationHandler).Queries("notification", "")
 	// DeleteBucket
 	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketHandler)
 
@@ -159,7 +175,10 @@ func registerAPIHandlers(mux *router.Router, a storageAPI, w *webAPI) {
 }
 
 // configureServer handler returns final handler for the http server.
-func configureServerHandler(filesystem fs.Filesystem) http.Handler {
+// filesystem is the ObjectLayer backend picked by the caller - the local
+// POSIX driver for "minio server", or a gateway driver for
+// "minio gateway <backend>".
+func configureServerHandler(filesystem ObjectLayer) http.Handler {
 	// Access credentials.
 	cred := serverConfig.GetCredential()
 
@@ -176,6 +195,7 @@ func configureServerHandler(filesystem fs.Filesystem) http.Handler {
 	api := storageAPI{
 		Filesystem: filesystem,
 		Signature:  sign,
+		Notifier:   notify.NewNotifier(),
 	}
 
 	// Split host port.
@@ -195,6 +215,7 @@ func configureServerHandler(filesystem fs.Filesystem) http.Handler {
 	web := &webAPI{
 		FSPath:          filesystem.GetRootPath(),
 		Client:          client,
+		Filesystem:      filesystem,
 		apiAddress:      addr,
 		accessKeyID:     cred.AccessKeyID,
 		secretAccessKey: cred.SecretAccessKey,
@@ -0,0 +1,190 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// minPresignExpiry and maxPresignExpiry bound the Expires field accepted
+// by the presigned URL RPCs, matching the limits signature V4 itself
+// enforces on X-Amz-Expires.
+const (
+	minPresignExpiry = 1 * time.Second
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// PresignedGetObjectArgs - presigned get object RPC arguments.
+type PresignedGetObjectArgs struct {
+	// BucketName is bucket name for the presigned request.
+	BucketName string
+	// ObjectName is object name for the presigned request.
+	ObjectName string
+	// Expires is presigned URL validity duration.
+	Expires time.Duration
+}
+
+// PresignedPutObjectArgs - presigned put object RPC arguments.
+type PresignedPutObjectArgs struct {
+	// BucketName is bucket name for the presigned request.
+	BucketName string
+	// ObjectName is object name for the presigned request.
+	ObjectName string
+	// Expires is presigned URL validity duration.
+	Expires time.Duration
+}
+
+// PresignedURLReply - presigned URL RPC reply.
+type PresignedURLReply struct {
+	// URL is the generated presigned URL.
+	URL string
+}
+
+// bucketPolicyStatement is the minimal subset of an AWS S3 bucket
+// policy statement validatePresignArgs needs to answer "would this
+// action be denied" - it is not a general purpose policy engine, it
+// only recognizes an explicit Deny naming the action and resource.
+type bucketPolicyStatement struct {
+	Effect   string             `json:"Effect"`
+	Action   policyStringOrList `json:"Action"`
+	Resource policyStringOrList `json:"Resource"`
+}
+
+// policyStringOrList unmarshals either a bare JSON string or an array
+// of strings, matching how Action/Resource may appear in a bucket
+// policy statement.
+type policyStringOrList []string
+
+func (s *policyStringOrList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = policyStringOrList{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*s = policyStringOrList(multi)
+	return nil
+}
+
+func (s policyStringOrList) matches(v string) bool {
+	for _, entry := range s {
+		if entry == v || entry == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// policyResourceMatches reports whether an S3 ARN resource pattern
+// (e.g. "arn:aws:s3:::bucket/*") covers bucket/object, a trailing "*"
+// is the only wildcard form handled.
+func policyResourceMatches(pattern, bucket, object string) bool {
+	want := "arn:aws:s3:::" + bucket + "/" + object
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+		return strings.HasPrefix(want, prefix)
+	}
+	return pattern == want
+}
+
+// presignActionDenied reports whether policyJSON carries an explicit
+// Deny statement covering action against bucket/object. A malformed or
+// empty document is treated as "not denied" - validatePresignArgs
+// already confirmed the bucket exists, an unparsable policy isn't
+// reason enough to fail every presign request against it.
+func presignActionDenied(policyJSON, action, bucket, object string) bool {
+	if policyJSON == "" {
+		return false
+	}
+	var doc struct {
+		Statement []bucketPolicyStatement `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(policyJSON), &doc); err != nil {
+		return false
+	}
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != "Deny" || !stmt.Action.matches(action) {
+			continue
+		}
+		for _, resource := range stmt.Resource {
+			if policyResourceMatches(resource, bucket, object) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validatePresignArgs validates bucket/object names and expiry,
+// confirms the bucket exists, and denies minting a presigned URL for
+// action when the bucket's policy carries an explicit Deny covering
+// it.
+func (web *webAPI) validatePresignArgs(bucket, object string, expires time.Duration, action string) error {
+	if bucket == "" || object == "" {
+		return errors.New("bucket and object names are required")
+	}
+	if expires < minPresignExpiry || expires > maxPresignExpiry {
+		return errors.New("expires must be between 1s and 7d")
+	}
+	if _, err := web.Filesystem.GetBucketMetadata(bucket); err != nil {
+		return err.Trace(bucket)
+	}
+	policyJSON, err := web.Filesystem.GetBucketPolicy(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	if presignActionDenied(policyJSON, action, bucket, object) {
+		return errors.New("bucket policy denies " + action + " on " + bucket + "/" + object)
+	}
+	return nil
+}
+
+// PresignedGetObject - generates a presigned URL for GET so the browser
+// can download large objects directly from the S3 API, without
+// streaming the bytes through the JSON-RPC channel.
+func (web *webAPI) PresignedGetObject(r *http.Request, args *PresignedGetObjectArgs, reply *PresignedURLReply) error {
+	if err := web.validatePresignArgs(args.BucketName, args.ObjectName, args.Expires, "s3:GetObject"); err != nil {
+		return err
+	}
+	url, e := web.Client.PresignedGetObject(args.BucketName, args.ObjectName, args.Expires)
+	if e != nil {
+		return e
+	}
+	reply.URL = url
+	return nil
+}
+
+// PresignedPutObject - generates a presigned URL for PUT so the browser
+// can upload large objects directly to the S3 API, without streaming
+// the bytes through the JSON-RPC channel.
+func (web *webAPI) PresignedPutObject(r *http.Request, args *PresignedPutObjectArgs, reply *PresignedURLReply) error {
+	if err := web.validatePresignArgs(args.BucketName, args.ObjectName, args.Expires, "s3:PutObject"); err != nil {
+		return err
+	}
+	url, e := web.Client.PresignedPutObject(args.BucketName, args.ObjectName, args.Expires)
+	if e != nil {
+		return e
+	}
+	reply.URL = url
+	return nil
+}
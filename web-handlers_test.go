@@ -0,0 +1,149 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/fs"
+	"github.com/minio/minio/pkg/probe"
+)
+
+// stubObjectLayer implements ObjectLayer, reporting every bucket as
+// existing and returning zero values with no error from every other
+// operation - only GetBucketMetadata and GetBucketPolicy are exercised
+// by validatePresignArgs.
+type stubObjectLayer struct {
+	// policyJSON is returned by GetBucketPolicy for every bucket.
+	policyJSON string
+}
+
+func (stubObjectLayer) GetRootPath() string { return "" }
+
+func (stubObjectLayer) ListBuckets() ([]fs.BucketMetadata, *probe.Error) { return nil, nil }
+
+func (stubObjectLayer) MakeBucket(bucket string) *probe.Error { return nil }
+
+func (stubObjectLayer) GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error) {
+	return fs.BucketMetadata{Name: bucket}, nil
+}
+
+func (stubObjectLayer) SetBucketMetadata(bucket string, metadata map[string]string) *probe.Error {
+	return nil
+}
+
+func (s stubObjectLayer) GetBucketPolicy(bucket string) (string, *probe.Error) {
+	return s.policyJSON, nil
+}
+
+func (stubObjectLayer) DeleteBucket(bucket string) *probe.Error { return nil }
+
+func (stubObjectLayer) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (fs.ListObjectsResult, *probe.Error) {
+	return fs.ListObjectsResult{}, nil
+}
+
+func (stubObjectLayer) GetObject(w io.Writer, bucket, object string, startOffset int64) (int64, *probe.Error) {
+	return 0, nil
+}
+
+func (stubObjectLayer) GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error) {
+	return fs.ObjectMetadata{}, nil
+}
+
+func (stubObjectLayer) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	return fs.ObjectMetadata{}, nil
+}
+
+func (stubObjectLayer) DeleteObject(bucket, object string) *probe.Error { return nil }
+
+func (stubObjectLayer) CopyObject(bucket, object, sourceBucket, sourceObject string, metadata map[string]string) (fs.ObjectMetadata, *probe.Error) {
+	return fs.ObjectMetadata{}, nil
+}
+
+func (stubObjectLayer) ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error) {
+	return resources, nil
+}
+
+func (stubObjectLayer) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	return "", nil
+}
+
+func (stubObjectLayer) PutObjectPart(bucket, object, uploadID string, partNumber int, expectedMD5Sum string, size int64, data io.Reader) (fs.PartMetadata, *probe.Error) {
+	return fs.PartMetadata{}, nil
+}
+
+func (stubObjectLayer) ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error) {
+	return resources, nil
+}
+
+func (stubObjectLayer) CompleteMultipartUpload(bucket, object, uploadID string, parts map[int]string) (fs.ObjectMetadata, *probe.Error) {
+	return fs.ObjectMetadata{}, nil
+}
+
+func (stubObjectLayer) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	return nil
+}
+
+func TestValidatePresignArgsExpiryBounds(t *testing.T) {
+	web := &webAPI{Filesystem: stubObjectLayer{}}
+
+	testCases := []struct {
+		expires time.Duration
+		wantErr bool
+	}{
+		{0, true},
+		{500 * time.Millisecond, true},
+		{minPresignExpiry, false},
+		{time.Hour, false},
+		{maxPresignExpiry, false},
+		{maxPresignExpiry + time.Second, true},
+	}
+	for _, testCase := range testCases {
+		err := web.validatePresignArgs("bucket", "object", testCase.expires, "s3:GetObject")
+		if testCase.wantErr && err == nil {
+			t.Errorf("expires=%s: expected an error, got none", testCase.expires)
+		}
+		if !testCase.wantErr && err != nil {
+			t.Errorf("expires=%s: expected no error, got %v", testCase.expires, err)
+		}
+	}
+}
+
+func TestValidatePresignArgsRequiresBucketAndObject(t *testing.T) {
+	web := &webAPI{Filesystem: stubObjectLayer{}}
+
+	if err := web.validatePresignArgs("", "object", time.Hour, "s3:GetObject"); err == nil {
+		t.Error("expected an error for an empty bucket name")
+	}
+	if err := web.validatePresignArgs("bucket", "", time.Hour, "s3:GetObject"); err == nil {
+		t.Error("expected an error for an empty object name")
+	}
+}
+
+func TestValidatePresignArgsDeniesPolicyMatch(t *testing.T) {
+	denyGet := `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`
+	web := &webAPI{Filesystem: stubObjectLayer{policyJSON: denyGet}}
+
+	if err := web.validatePresignArgs("bucket", "object", time.Hour, "s3:GetObject"); err == nil {
+		t.Error("expected an error when the bucket policy explicitly denies s3:GetObject")
+	}
+	if err := web.validatePresignArgs("bucket", "object", time.Hour, "s3:PutObject"); err != nil {
+		t.Errorf("expected no error for an action the policy doesn't deny, got %v", err)
+	}
+}